@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewrev/mapfile"
+	"github.com/mewrev/mapfile/convert"
+)
+
+// dumpBinaryNinjaScript converts the given symbol map file to a Python
+// script for loading the symbols into Binary Ninja, to be run through its
+// headless API against an open BinaryView bound to bv.
+func dumpBinaryNinjaScript(m *mapfile.Map) {
+	for _, sym := range m.Syms {
+		name := convert.SymbolName(sym)
+		symType := "DataSymbol"
+		if sym.IsFunc {
+			symType = "FunctionSymbol"
+		}
+		fmt.Printf("bv.define_user_symbol(Symbol(SymbolType.%s, 0x%08X, %q))\n", symType, sym.Addr, name)
+	}
+}
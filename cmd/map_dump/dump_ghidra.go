@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewrev/mapfile"
+	"github.com/mewrev/mapfile/convert"
+)
+
+// dumpGhidraScript converts the given symbol map file to a Python script
+// for loading the symbols into Ghidra, to be run through its Jython
+// headless script runner.
+func dumpGhidraScript(m *mapfile.Map) {
+	fmt.Println("from ghidra.program.model.symbol import SourceType")
+	for _, sym := range m.Syms {
+		name := convert.SymbolName(sym)
+		fmt.Printf("createLabel(toAddr(0x%08X), %q, True, SourceType.USER_DEFINED)\n", sym.Addr, name)
+	}
+}
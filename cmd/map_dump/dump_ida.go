@@ -0,0 +1,15 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewrev/mapfile"
+)
+
+// dumpIdaScript converts the given symbol map file to a Python script for
+// loading the symbols into IDA.
+func dumpIdaScript(m *mapfile.Map) {
+	for _, sym := range m.Syms {
+		fmt.Printf("set_name(0x%08X, \"%s\", SN_NOWARN)\n", sym.Addr, sym.MangledName)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewrev/mapfile"
+	"github.com/mewrev/mapfile/convert"
+)
+
+// dumpPprofSymbols converts the given symbol map file to the nm-style text
+// symbol table (address, type, name per line) that pprof's offline
+// symbolizer accepts in place of shelling out to nm.
+func dumpPprofSymbols(m *mapfile.Map) {
+	for _, sym := range m.Syms {
+		typ := byte('D')
+		if sym.IsFunc {
+			typ = 'T'
+		}
+		if sym.IsStatic {
+			typ += 'a' - 'A' // lowercase denotes a local (static) symbol.
+		}
+		name := convert.SymbolName(sym)
+		fmt.Printf("%016x %c %s\n", sym.Addr, typ, name)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mewrev/mapfile"
+	"github.com/mewrev/mapfile/convert"
+)
+
+// dumpRadare2Script converts the given symbol map file to an r2 script
+// (consumed with `r2 -i`) that flags each symbol's address with its name.
+func dumpRadare2Script(m *mapfile.Map) {
+	for _, sym := range m.Syms {
+		name := convert.SymbolName(sym)
+		fmt.Printf("f sym.%s @ 0x%08X\n", name, sym.Addr)
+	}
+}
@@ -2,27 +2,36 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"log"
 
 	"github.com/mewrev/mapfile"
 )
 
+// dumper generates disassembler/profiler input from a parsed MAP file.
+type dumper func(m *mapfile.Map)
+
+// dumpers maps the -fmt flag value to the dumper that emits that tool's
+// symbol format.
+var dumpers = map[string]dumper{
+	"ida":     dumpIdaScript,
+	"ghidra":  dumpGhidraScript,
+	"radare2": dumpRadare2Script,
+	"binja":   dumpBinaryNinjaScript,
+	"pprof":   dumpPprofSymbols,
+}
+
 func main() {
+	format := flag.String("fmt", "ida", "output format (ida, ghidra, radare2, binja, pprof)")
 	flag.Parse()
+	dump, ok := dumpers[*format]
+	if !ok {
+		log.Fatalf("unknown output format %q", *format)
+	}
 	for _, mapPath := range flag.Args() {
 		m, err := mapfile.ParseFile(mapPath)
 		if err != nil {
 			log.Fatalf("%+v", err)
 		}
-		dumpIdaScript(m)
-	}
-}
-
-// dumpIdaScript converts the given symbol map file to a Python script for
-// loading the symbols into IDA.
-func dumpIdaScript(m *mapfile.Map) {
-	for _, sym := range m.Syms {
-		fmt.Printf("set_name(0x%08X, \"%s\", SN_NOWARN)\n", sym.Addr, sym.MangledName)
+		dump(m)
 	}
 }
@@ -0,0 +1,110 @@
+// Package convert converts parsed MAP files into the symbol table
+// representations used by Go's debug/elf and debug/gosym packages, so that
+// tools speaking those interfaces (disassemblers, profilers, the objfile
+// package backing go tool objdump and pprof) can consume MAP data without
+// bespoke glue.
+package convert
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"sort"
+
+	"github.com/mewrev/mapfile"
+)
+
+// SymbolName returns sym's demangled name if known, falling back to its
+// mangled name otherwise.
+func SymbolName(sym *mapfile.Symbol) string {
+	if sym.Name != "" {
+		return sym.Name
+	}
+	return sym.MangledName
+}
+
+// ToElfSymbols converts m's symbols into the elf.Symbol representation used
+// by debug/elf symbol tables.
+func ToElfSymbols(m *mapfile.Map) []elf.Symbol {
+	syms := make([]elf.Symbol, 0, len(m.Syms))
+	for _, sym := range m.Syms {
+		typ := elf.STT_OBJECT
+		if sym.IsFunc {
+			typ = elf.STT_FUNC
+		}
+		bind := elf.STB_GLOBAL
+		if sym.IsStatic {
+			bind = elf.STB_LOCAL
+		}
+		syms = append(syms, elf.Symbol{
+			Name:    SymbolName(sym),
+			Info:    elf.ST_INFO(bind, typ),
+			Section: elf.SHN_UNDEF,
+			Value:   sym.Addr,
+			Size:    sym.Size,
+		})
+	}
+	return syms
+}
+
+// ToGosymTable converts m's symbols into a debug/gosym.Table, with a Func
+// for each function symbol, suitable for PC-to-function lookups through PCToFunc and LookupFunc.
+// Line number information is unavailable from a MAP file, so the returned
+// table answers address and name queries only; PCToLine always reports no
+// line information.
+func ToGosymTable(m *mapfile.Map) *gosym.Table {
+	var syms []gosym.Sym
+	var funcs []gosym.Func
+	for _, sym := range m.Syms {
+		gs := gosym.Sym{
+			Value: sym.Addr,
+			Name:  SymbolName(sym),
+		}
+		if sym.IsFunc {
+			gs.Type = 'T'
+		} else {
+			gs.Type = 'D'
+		}
+		if sym.IsStatic {
+			gs.Type += 'a' - 'A' // lowercase denotes a local (static) symbol.
+		}
+		syms = append(syms, gs)
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		return syms[i].Value < syms[j].Value
+	})
+	for i := range syms {
+		s := &syms[i]
+		if s.Type != 'T' && s.Type != 't' {
+			continue
+		}
+		// A function's End is the next known symbol's address, mirroring
+		// Symbol.Size's "next symbol in the section" sentinel; a function
+		// with no symbol after it has End equal to its Entry.
+		end := s.Value
+		for _, next := range syms[i+1:] {
+			if next.Value > s.Value {
+				end = next.Value
+				break
+			}
+		}
+		fn := gosym.Func{
+			Entry: s.Value,
+			Sym:   s,
+			End:   end,
+			// Non-nil but empty: (*gosym.Table).PCToLine dereferences both
+			// unconditionally once go12line is unset, which it always is
+			// here; a nil LineTable or Obj would panic instead of resolving
+			// to the empty line info promised above.
+			LineTable: &gosym.LineTable{},
+			Obj:       &gosym.Obj{},
+		}
+		funcs = append(funcs, fn)
+	}
+	for i := range funcs {
+		funcs[i].Sym.Func = &funcs[i]
+	}
+	return &gosym.Table{
+		Syms:  syms,
+		Funcs: funcs,
+	}
+}
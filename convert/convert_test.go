@@ -0,0 +1,29 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/mewrev/mapfile"
+)
+
+func TestToGosymTablePCToLine(t *testing.T) {
+	m := &mapfile.Map{
+		Syms: []*mapfile.Symbol{
+			{Name: "main.main", Addr: 0x401000, IsFunc: true},
+			{Name: "main.helper", Addr: 0x401100, IsFunc: true},
+			{Name: "g_data", Addr: 0x402000},
+		},
+	}
+	table := ToGosymTable(m)
+
+	file, line, fn := table.PCToLine(0x401050)
+	if fn == nil {
+		t.Fatal("PCToLine: fn = nil, want main.main's *gosym.Func")
+	}
+	if fn.Sym.Name != "main.main" {
+		t.Errorf("PCToLine: fn.Sym.Name = %q, want %q", fn.Sym.Name, "main.main")
+	}
+	if file != "" || line != 0 {
+		t.Errorf("PCToLine = (%q, %d), want (\"\", 0)", file, line)
+	}
+}
@@ -0,0 +1,274 @@
+package mapfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Event is a unit of information decoded from a MAP file by a Decoder. It is
+// one of HeaderEvent, SectionEvent, SymbolEvent or EntryPointEvent.
+type Event interface {
+	isEvent()
+}
+
+// HeaderEvent reports the name, link date and base address of a MAP file's
+// linker output. Dialect is always set; Name, Date and BaseAddr may be the
+// zero value if the dialect does not encode them (e.g. a GNU MAP file never
+// reports a date or base address, and reports a name only through a
+// trailing OUTPUT directive).
+type HeaderEvent struct {
+	Dialect  Dialect
+	Name     string
+	Date     time.Time
+	BaseAddr uint64
+}
+
+func (HeaderEvent) isEvent() {}
+
+// SectionEvent reports a single decoded section.
+type SectionEvent struct {
+	Section *Section
+}
+
+func (SectionEvent) isEvent() {}
+
+// SymbolEvent reports a single decoded symbol.
+type SymbolEvent struct {
+	Symbol *Symbol
+}
+
+func (SymbolEvent) isEvent() {}
+
+// EntryPointEvent reports the segment relative offset to the entry point.
+type EntryPointEvent struct {
+	Entry SegmentOffset
+}
+
+func (EntryPointEvent) isEvent() {}
+
+// ParseError describes a single line of a MAP file that could not be
+// parsed.
+type ParseError struct {
+	// Line is the 1-based line number of the offending line.
+	Line int
+	// Text is the raw (whitespace-trimmed) text of the offending line.
+	Text string
+	// Err is the underlying cause.
+	Err error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Text, e.Err)
+}
+
+// Cause returns the underlying cause, for compatibility with
+// github.com/pkg/errors.Cause.
+func (e *ParseError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying cause, for compatibility with errors.Is and
+// errors.As.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHandler is invoked by Decoder.Next for each line that fails to
+// parse. Returning nil skips the offending line and resumes decoding with
+// the next line; returning a non-nil error aborts decoding, and that error
+// (rather than the original *ParseError) is returned from Next. A nil
+// ErrorHandler aborts decoding on the first error, returning its
+// *ParseError from Next.
+type ErrorHandler func(err *ParseError) error
+
+// dialectSniffWindow bounds how many lines a Decoder buffers while
+// searching for a dialect marker before giving up and assuming DialectMSVC,
+// so that auto-detection never requires buffering an entire large MAP
+// file.
+const dialectSniffWindow = 4096
+
+// decoderState decodes the events of one MAP file dialect, one line at a
+// time.
+type decoderState interface {
+	// step decodes the next event, if any, from line. A nil Event with a
+	// nil error means the line carried no event (e.g. it was blank, or
+	// updated internal state consulted by a later line).
+	step(line string) (Event, error)
+}
+
+// Decoder reads a MAP file incrementally, decoding one Event at a time
+// without requiring the entire file to be held in memory at once.
+type Decoder struct {
+	// ErrorHandler, if set, is consulted for every line that fails to
+	// parse; see ErrorHandler.
+	ErrorHandler ErrorHandler
+
+	sc          *bufio.Scanner
+	lineNum     int
+	dialect     Dialect
+	haveDialect bool
+	lookahead   []string
+	state       decoderState
+}
+
+// NewDecoder returns a Decoder that auto-detects the MAP file dialect from
+// the contents of r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{sc: bufio.NewScanner(r)}
+}
+
+// NewDecoderDialect returns a Decoder that decodes r as the given MAP file
+// dialect, skipping auto-detection.
+func NewDecoderDialect(r io.Reader, dialect Dialect) *Decoder {
+	return &Decoder{sc: bufio.NewScanner(r), dialect: dialect, haveDialect: true}
+}
+
+// nextLine returns the next whitespace-trimmed line of the MAP file, and
+// false once the file is exhausted.
+func (d *Decoder) nextLine() (string, bool) {
+	if len(d.lookahead) > 0 {
+		line := d.lookahead[0]
+		d.lookahead = d.lookahead[1:]
+		d.lineNum++
+		return line, true
+	}
+	if !d.sc.Scan() {
+		return "", false
+	}
+	d.lineNum++
+	return strings.TrimSpace(d.sc.Text()), true
+}
+
+// ensureDialect determines d's MAP file dialect, buffering and replaying a
+// bounded window of lines read while sniffing for a dialect marker.
+func (d *Decoder) ensureDialect() error {
+	if d.haveDialect {
+		return nil
+	}
+	var buffered []string
+	var dialect Dialect
+	for len(buffered) < dialectSniffWindow {
+		line, ok := d.nextLine()
+		if !ok {
+			break
+		}
+		buffered = append(buffered, line)
+		switch {
+		case strings.HasPrefix(line, "Preferred load address is "):
+			dialect = DialectMSVC
+		case strings.HasPrefix(line, "Memory Configuration"),
+			strings.HasPrefix(line, "Linker script and memory map"):
+			dialect = DialectGNU
+		}
+		if dialect != 0 {
+			break
+		}
+	}
+	if dialect == 0 {
+		// No marker found within the sniff window; default to DialectMSVC
+		// for back-compatibility with MAP files that predate dialect
+		// detection.
+		dialect = DialectMSVC
+	}
+	d.dialect = dialect
+	d.haveDialect = true
+	// Replay the lines consumed while sniffing, ahead of anything already
+	// queued.
+	d.lookahead = append(buffered, d.lookahead...)
+	d.lineNum -= len(buffered)
+	return nil
+}
+
+// Next decodes and returns the next Event in the MAP file, or io.EOF once
+// the file is exhausted.
+func (d *Decoder) Next() (Event, error) {
+	if err := d.ensureDialect(); err != nil {
+		return nil, err
+	}
+	if d.state == nil {
+		switch d.dialect {
+		case DialectMSVC:
+			d.state = &msvcState{}
+		case DialectGNU:
+			d.state = &gnuState{sects: make(map[string]*Section)}
+		default:
+			return nil, errors.Errorf("support for MAP file dialect %v not yet implemented", d.dialect)
+		}
+	}
+	for {
+		line, ok := d.nextLine()
+		if !ok {
+			return nil, io.EOF
+		}
+		event, err := d.state.step(line)
+		if err != nil {
+			perr := &ParseError{Line: d.lineNum, Text: line, Err: err}
+			if d.ErrorHandler != nil {
+				if herr := d.ErrorHandler(perr); herr != nil {
+					return nil, herr
+				}
+				continue
+			}
+			return nil, perr
+		}
+		if event != nil {
+			return event, nil
+		}
+	}
+}
+
+// DecodeAll decodes every event of the MAP file read from r, reconstructing
+// the equivalent *Map that Parse returns.
+func DecodeAll(r io.Reader, opts ...ParseOption) (*Map, error) {
+	return decodeAll(NewDecoder(r), opts)
+}
+
+// DecodeAllDialect decodes every event of the MAP file of the given dialect
+// read from r, reconstructing the equivalent *Map that ParseDialect
+// returns.
+func DecodeAllDialect(r io.Reader, dialect Dialect, opts ...ParseOption) (*Map, error) {
+	return decodeAll(NewDecoderDialect(r, dialect), opts)
+}
+
+// decodeAll drains d, assembling its events into a *Map.
+func decodeAll(d *Decoder, opts []ParseOption) (*Map, error) {
+	cfg := newParseConfig(opts)
+	d.ErrorHandler = cfg.errHandler
+	m := &Map{}
+	for {
+		event, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := event.(type) {
+		case HeaderEvent:
+			m.Dialect = e.Dialect
+			if e.Name != "" {
+				m.Name = e.Name
+			}
+			if !e.Date.IsZero() {
+				m.Date = e.Date
+			}
+			if e.BaseAddr != 0 {
+				m.BaseAddr = e.BaseAddr
+			}
+		case SectionEvent:
+			m.Sects = append(m.Sects, e.Section)
+		case SymbolEvent:
+			m.Syms = append(m.Syms, e.Symbol)
+		case EntryPointEvent:
+			m.Entry = e.Entry
+		}
+	}
+	demangleSyms(m, cfg)
+	return m, nil
+}
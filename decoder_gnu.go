@@ -0,0 +1,127 @@
+package mapfile
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// gnuState decodes the events of a GNU-dialect MAP file, one line at a
+// time; see decoderState.
+type gnuState struct {
+	inMap         bool
+	headerEmitted bool
+	curObject     string
+	// inDiscard reports whether the decoder is inside a "Discarded input
+	// sections" block, terminated by a blank line; its entries describe
+	// sections the linker dropped, and carry no linkage information worth
+	// reporting.
+	inDiscard bool
+	// sects tracks section names already reported, so that a section's
+	// per-object sub-entries (which repeat its name) don't produce
+	// duplicate SectionEvents.
+	sects map[string]*Section
+	// curSectType is the type of the section most recently entered,
+	// applied to the symbols listed beneath it.
+	curSectType SectionType
+}
+
+// step implements decoderState.
+func (s *gnuState) step(line string) (Event, error) {
+	switch {
+	// Discarded input sections, terminated by a blank line.
+	//
+	//    Discarded input sections
+	//
+	//     .text          0x0000000000000000        0x0 /tmp/foo.o
+	case s.inDiscard:
+		if len(line) == 0 {
+			s.inDiscard = false
+		}
+		return nil, nil
+
+	case len(line) == 0:
+		return nil, nil
+
+	case strings.HasPrefix(line, "Linker script and memory map"):
+		s.inMap = true
+		if s.headerEmitted {
+			return nil, nil
+		}
+		s.headerEmitted = true
+		return HeaderEvent{Dialect: DialectGNU}, nil
+
+	// Archive member notes, common symbol table, memory configuration,
+	// etc. precede the linker script and memory map; skip them.
+	case !s.inMap:
+		return nil, nil
+
+	// OUTPUT(a.out elf64-x86-64)
+	case strings.HasPrefix(line, "OUTPUT("):
+		raw := strings.TrimSuffix(strings.TrimPrefix(line, "OUTPUT("), ")")
+		fields := strings.Fields(raw)
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		return HeaderEvent{Dialect: DialectGNU, Name: fields[0]}, nil
+
+	// LOAD /tmp/libbar.a(bar.o)
+	case strings.HasPrefix(line, "LOAD "):
+		s.curObject = gnuObjectName(strings.TrimSpace(strings.TrimPrefix(line, "LOAD ")))
+		return nil, nil
+
+	// Header introducing a block of discarded input sections.
+	case strings.HasPrefix(line, "Discarded input sections"):
+		s.inDiscard = true
+		return nil, nil
+
+	// Alignment padding between input sections (e.g. "*fill*   0x402000
+	// 0x4 00"); carries no symbol or section information of its own.
+	case strings.HasPrefix(line, "*fill*"):
+		return nil, nil
+
+	// Wildcard input section pattern (e.g. "*(.text)"); carries no address
+	// or size information of its own.
+	case strings.HasPrefix(line, "*("):
+		return nil, nil
+
+	default:
+		fields := strings.Fields(line)
+		switch {
+		// Section entry (aggregate or per-object sub-entry).
+		//
+		//    .text          0x0000000000401000      0x500 /tmp/foo.o
+		case len(fields) >= 3 && strings.HasPrefix(fields[0], "."):
+			sect, err := parseGNUSection(fields)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if len(fields) >= 4 {
+				s.curObject = gnuObjectName(fields[3])
+			}
+			s.curSectType = sect.Type
+			if _, ok := s.sects[sect.Name]; ok {
+				// Aggregate entry already reported; skip the duplicate
+				// size/address reported by the per-object sub-entry.
+				return nil, nil
+			}
+			s.sects[sect.Name] = sect
+			return SectionEvent{Section: sect}, nil
+
+		// Symbol entry.
+		//
+		//    0x0000000000401000                foo_func
+		case len(fields) == 2 && strings.HasPrefix(fields[0], "0x"):
+			sym, err := parseGNUSymbol(fields)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			sym.ObjectName = s.curObject
+			sym.IsFunc = s.curSectType == SectionTypeCode
+			return SymbolEvent{Symbol: sym}, nil
+
+		default:
+			return nil, errors.Errorf("support for line %q not yet implemented", line)
+		}
+	}
+}
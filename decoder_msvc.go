@@ -0,0 +1,130 @@
+package mapfile
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// msvcState decodes the events of an MSVC-dialect MAP file, one line at a
+// time; see decoderState.
+type msvcState struct {
+	sawFirstLine bool
+	name         string
+	date         time.Time
+
+	inSections bool
+
+	awaitingSymBlank bool
+	inSymbols        bool
+	symStatic        bool
+}
+
+// step implements decoderState.
+func (s *msvcState) step(line string) (Event, error) {
+	switch {
+	// First line is the name of the linker output.
+	case !s.sawFirstLine:
+		s.sawFirstLine = true
+		s.name = line
+		return nil, nil
+
+	// List of sections, terminated by a blank line.
+	//
+	//    0001:00000000 001012c6H .text                   CODE
+	case s.inSections:
+		if len(line) == 0 {
+			s.inSections = false
+			return nil, nil
+		}
+		sect, err := parseSection(line)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return SectionEvent{Section: sect}, nil
+
+	// List of symbols, terminated by a blank line.
+	//
+	//    0001:00000000       ?bar@@YIXH@Z               00401000 f baz.obj
+	case s.inSymbols:
+		if len(line) == 0 {
+			s.inSymbols = false
+			return nil, nil
+		}
+		sym, err := parseSymbol(line)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		sym.IsStatic = s.symStatic
+		return SymbolEvent{Symbol: sym}, nil
+
+	// Blank line separating a symbol list header from its list of symbols.
+	case s.awaitingSymBlank:
+		s.awaitingSymBlank = false
+		if len(line) != 0 {
+			return nil, errors.Errorf("unexpected line between header and list of symbols; expected empty line, got %q", line)
+		}
+		s.inSymbols = true
+		return nil, nil
+
+	// Link date.
+	case strings.HasPrefix(line, "Timestamp is "):
+		// Timestamp is 5e97f112 (Wed Apr 15 22:45:54 2020)
+		rawDate := line[len("Timestamp is 5e97f112 (") : len(line)-len(")")]
+		date, err := time.Parse(time.ANSIC, rawDate)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		s.date = date
+		return nil, nil
+
+	// Base address; the last header field, so this is where HeaderEvent is
+	// emitted.
+	case strings.HasPrefix(line, "Preferred load address is "):
+		// Preferred load address is 00400000
+		rawBaseAddr := line[len("Preferred load address is "):]
+		baseAddr, err := strconv.ParseUint(rawBaseAddr, 16, 64)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return HeaderEvent{Dialect: DialectMSVC, Name: s.name, Date: s.date, BaseAddr: baseAddr}, nil
+
+	// List of sections.
+	// Start         Length     Name                   Class
+	case hasFields(line, []string{"Start", "Length", "Name", "Class"}):
+		s.inSections = true
+		return nil, nil
+
+	// List of symbols.
+	// Address         Publics by Value              Rva+Base   Lib:Object
+	case hasFields(line, []string{"Address", "Publics", "by", "Value", "Rva+Base", "Lib:Object"}):
+		s.symStatic = false
+		s.awaitingSymBlank = true
+		return nil, nil
+	case strings.HasPrefix(line, "Static symbols"):
+		s.symStatic = true
+		s.awaitingSymBlank = true
+		return nil, nil
+
+	// Entry point.
+	case strings.HasPrefix(line, "entry point at"):
+		// entry point at        0001:000f0290
+		rawEntry := strings.TrimSpace(strings.TrimPrefix(line, "entry point at"))
+		entry, err := parseSegmentOffset(rawEntry)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return EntryPointEvent{Entry: entry}, nil
+
+	case strings.HasPrefix(line, "FIXUPS:"):
+		// ignore.
+		return nil, nil
+	case len(line) == 0:
+		// skip empty lines.
+		return nil, nil
+	default:
+		return nil, errors.Errorf("support for line prefix %q not yet implemented", line)
+	}
+}
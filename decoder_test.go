@@ -0,0 +1,122 @@
+package mapfile
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderNext(t *testing.T) {
+	const gnuMap = `Linker script and memory map
+
+.text           0x0000000000401000      0x500
+ *(.text)
+ .text          0x0000000000401000      0x500 /tmp/foo.o
+                0x0000000000401020                foo_func
+
+OUTPUT(a.out elf64-x86-64)
+`
+	d := NewDecoderDialect(strings.NewReader(gnuMap), DialectGNU)
+
+	event, err := d.Next()
+	if err != nil {
+		t.Fatalf("Next() #1 failed: %v", err)
+	}
+	if _, ok := event.(HeaderEvent); !ok {
+		t.Fatalf("Next() #1 = %T, want HeaderEvent", event)
+	}
+
+	event, err = d.Next()
+	if err != nil {
+		t.Fatalf("Next() #2 failed: %v", err)
+	}
+	sectEvent, ok := event.(SectionEvent)
+	if !ok {
+		t.Fatalf("Next() #2 = %T, want SectionEvent", event)
+	}
+	if sectEvent.Section.Name != ".text" {
+		t.Errorf("SectionEvent.Section.Name = %q, want %q", sectEvent.Section.Name, ".text")
+	}
+
+	event, err = d.Next()
+	if err != nil {
+		t.Fatalf("Next() #3 failed: %v", err)
+	}
+	symEvent, ok := event.(SymbolEvent)
+	if !ok {
+		t.Fatalf("Next() #3 = %T, want SymbolEvent", event)
+	}
+	if symEvent.Symbol.MangledName != "foo_func" {
+		t.Errorf("SymbolEvent.Symbol.MangledName = %q, want %q", symEvent.Symbol.MangledName, "foo_func")
+	}
+
+	event, err = d.Next()
+	if err != nil {
+		t.Fatalf("Next() #4 failed: %v", err)
+	}
+	if hdr, ok := event.(HeaderEvent); !ok || hdr.Name != "a.out" {
+		t.Fatalf("Next() #4 = %#v, want HeaderEvent{Name: %q}", event, "a.out")
+	}
+
+	if _, err := d.Next(); err != io.EOF {
+		t.Fatalf("Next() #5 = %v, want io.EOF", err)
+	}
+}
+
+func TestWithErrorHandlerSkip(t *testing.T) {
+	const msvcMap = ` foo.exe
+Timestamp is 5e97f112 (Wed Apr 15 22:45:54 2020)
+
+Preferred load address is 00400000
+
+GARBAGE LINE HERE
+
+ Start         Length     Name                   Class
+ 0001:00000000 00001000H .text                   CODE
+
+ Address         Publics by Value              Rva+Base   Lib:Object
+
+ 0001:00000000       _main                      00401000 f foo.obj
+
+ entry point at        0001:00000000
+
+ Static symbols
+
+`
+	var skipped []*ParseError
+	handler := func(err *ParseError) error {
+		skipped = append(skipped, err)
+		return nil
+	}
+	m, err := ParseDialect(strings.NewReader(msvcMap), DialectMSVC, WithErrorHandler(handler))
+	if err != nil {
+		t.Fatalf("ParseDialect failed: %v", err)
+	}
+	if len(skipped) != 1 {
+		t.Fatalf("len(skipped) = %d, want 1", len(skipped))
+	}
+	if skipped[0].Text != "GARBAGE LINE HERE" {
+		t.Errorf("skipped[0].Text = %q, want %q", skipped[0].Text, "GARBAGE LINE HERE")
+	}
+	if findSymbol(m, "_main") == nil {
+		t.Error("_main not found; parsing should have continued past the skipped line")
+	}
+}
+
+func TestErrorHandlerAbort(t *testing.T) {
+	const msvcMap = ` foo.exe
+Timestamp is 5e97f112 (Wed Apr 15 22:45:54 2020)
+
+Preferred load address is 00400000
+
+GARBAGE LINE HERE
+`
+	_, err := ParseDialect(strings.NewReader(msvcMap), DialectMSVC)
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("err = %T, want *ParseError", err)
+	}
+	if perr.Text != "GARBAGE LINE HERE" {
+		t.Errorf("perr.Text = %q, want %q", perr.Text, "GARBAGE LINE HERE")
+	}
+}
@@ -0,0 +1,201 @@
+package mapfile
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Demangler demangles a mangled symbol name produced by a particular
+// toolchain's name-mangling scheme.
+type Demangler interface {
+	// Demangle demangles the given mangled symbol name.
+	Demangle(mangled string) (string, error)
+}
+
+// ParseOption configures the behaviour of Parse and its variants.
+type ParseOption func(*parseConfig)
+
+// parseConfig tracks the options set through one or more ParseOption values.
+type parseConfig struct {
+	// demanglers are consulted, in order, to populate Symbol.Name; the first
+	// demangler able to demangle a given Symbol.MangledName wins.
+	demanglers []Demangler
+	// errHandler, if set, is invoked by Decoder.Next for each line that
+	// fails to parse.
+	errHandler ErrorHandler
+}
+
+// newParseConfig builds a parseConfig from the given options.
+func newParseConfig(opts []ParseOption) *parseConfig {
+	cfg := &parseConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithDemangler appends d to the list of demanglers consulted when
+// populating Symbol.Name. Demanglers are tried in the order they were
+// added; pass multiple WithDemangler options to support MAP files produced
+// by a mix of toolchains (e.g. MSVC C++ alongside mingw-emitted Itanium
+// names).
+func WithDemangler(d Demangler) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.demanglers = append(cfg.demanglers, d)
+	}
+}
+
+// WithErrorHandler sets the handler invoked by the underlying Decoder for
+// each line of the MAP file that fails to parse; see ErrorHandler.
+func WithErrorHandler(h ErrorHandler) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.errHandler = h
+	}
+}
+
+// demangleSyms populates Name on each of m.Syms by demangling
+// MangledName with the demanglers configured on cfg, leaving Name empty
+// for symbols no configured demangler recognises.
+func demangleSyms(m *Map, cfg *parseConfig) {
+	if len(cfg.demanglers) == 0 {
+		return
+	}
+	for _, sym := range m.Syms {
+		for _, d := range cfg.demanglers {
+			name, err := d.Demangle(sym.MangledName)
+			if err != nil {
+				continue
+			}
+			sym.Name = name
+			break
+		}
+	}
+}
+
+// MSVCDemangler demangles symbol names produced by the MSVC toolchain:
+// decorated C++ names (e.g. "?bar@@YIXH@Z"), import descriptors (e.g.
+// "__IMPORT_DESCRIPTOR_KERNEL32"), and stdcall-decorated C names (e.g.
+// "_WinMain@16").
+type MSVCDemangler struct{}
+
+// msvcImportDescRe matches the import descriptor symbol MSVC emits for each
+// imported DLL.
+var msvcImportDescRe = regexp.MustCompile(`^__IMPORT_DESCRIPTOR_(.+)$`)
+
+// msvcStdcallRe matches a stdcall-decorated C symbol name, capturing the
+// undecorated identifier.
+var msvcStdcallRe = regexp.MustCompile(`^_([A-Za-z_][A-Za-z0-9_]*)@[0-9]+$`)
+
+// Demangle implements Demangler.
+func (MSVCDemangler) Demangle(mangled string) (string, error) {
+	switch {
+	case strings.HasPrefix(mangled, "?"):
+		return demangleMSVCName(mangled)
+	case msvcImportDescRe.MatchString(mangled):
+		sub := msvcImportDescRe.FindStringSubmatch(mangled)
+		return sub[1], nil
+	case msvcStdcallRe.MatchString(mangled):
+		sub := msvcStdcallRe.FindStringSubmatch(mangled)
+		return sub[1], nil
+	default:
+		return "", errors.Errorf("unrecognized MSVC mangled name %q", mangled)
+	}
+}
+
+// demangleMSVCName demangles the unqualified identifier portion of a
+// decorated MSVC C++ name, i.e. the text preceding the first "@@"
+// scope/type separator.
+//
+// Full MSVC decoration also encodes calling convention, parameter and
+// return types, template arguments and enclosing scopes; decoding those is
+// beyond this best-effort demangler, which targets the common case of
+// locating a symbol by its unqualified name.
+func demangleMSVCName(mangled string) (string, error) {
+	rest := strings.TrimPrefix(mangled, "?")
+	i := strings.Index(rest, "@@")
+	if i < 0 {
+		return "", errors.Errorf(`malformed MSVC mangled name %q: missing "@@" separator`, mangled)
+	}
+	return rest[:i], nil
+}
+
+// ItaniumDemangler demangles symbol names produced by the Itanium C++ ABI
+// mangling scheme used by GCC, Clang and mingw (e.g. "_ZN3foo3barEv").
+type ItaniumDemangler struct{}
+
+// Demangle implements Demangler.
+func (ItaniumDemangler) Demangle(mangled string) (string, error) {
+	rest := strings.TrimPrefix(mangled, "_Z")
+	if rest == mangled {
+		return "", errors.Errorf("unrecognized Itanium mangled name %q", mangled)
+	}
+	d := &itaniumDecoder{s: rest}
+	name, err := d.parseName()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return name, nil
+}
+
+// itaniumDecoder decodes the <name> production of the Itanium C++ ABI
+// mangling grammar, tracking the unconsumed suffix of the mangled name.
+//
+// Only the productions needed to recover a "::"-joined symbol name are
+// implemented; the parameter and return type encodings that follow a
+// mangled function name are left unconsumed and ignored.
+type itaniumDecoder struct {
+	s string
+}
+
+// parseName parses a <name> production.
+func (d *itaniumDecoder) parseName() (string, error) {
+	if strings.HasPrefix(d.s, "N") {
+		d.s = d.s[len("N"):]
+		return d.parseNestedName()
+	}
+	return d.parseSourceName()
+}
+
+// parseNestedName parses a <nested-name> production, i.e. the components of
+// a name enclosed in "N" ... "E", joining them with "::".
+func (d *itaniumDecoder) parseNestedName() (string, error) {
+	var parts []string
+	for len(d.s) > 0 && d.s[0] != 'E' {
+		part, err := d.parseSourceName()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		parts = append(parts, part)
+	}
+	if len(d.s) == 0 {
+		return "", errors.Errorf(`malformed Itanium nested name: missing terminating "E"`)
+	}
+	d.s = d.s[len("E"):]
+	return strings.Join(parts, "::"), nil
+}
+
+// parseSourceName parses a <source-name> production, a decimal length
+// prefix followed by that many bytes of identifier.
+func (d *itaniumDecoder) parseSourceName() (string, error) {
+	i := 0
+	for i < len(d.s) && d.s[i] >= '0' && d.s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return "", errors.Errorf("malformed Itanium source name %q: missing length prefix", d.s)
+	}
+	n, err := strconv.Atoi(d.s[:i])
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	d.s = d.s[i:]
+	if n > len(d.s) {
+		return "", errors.Errorf("malformed Itanium source name: length %d exceeds remaining input %q", n, d.s)
+	}
+	name := d.s[:n]
+	d.s = d.s[n:]
+	return name, nil
+}
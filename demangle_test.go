@@ -0,0 +1,64 @@
+package mapfile
+
+import "testing"
+
+func TestMSVCDemanglerDemangle(t *testing.T) {
+	cases := []struct {
+		mangled string
+		want    string
+		wantErr bool
+	}{
+		{mangled: "?bar@@YIXH@Z", want: "bar"},
+		{mangled: "__IMPORT_DESCRIPTOR_KERNEL32", want: "KERNEL32"},
+		{mangled: "_WinMain@16", want: "WinMain"},
+		{mangled: "not_a_mangled_name", wantErr: true},
+	}
+	var d MSVCDemangler
+	for _, c := range cases {
+		got, err := d.Demangle(c.mangled)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("Demangle(%q) = %q, <nil>, want error", c.mangled, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Demangle(%q) failed: %v", c.mangled, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("Demangle(%q) = %q, want %q", c.mangled, got, c.want)
+		}
+	}
+}
+
+func TestItaniumDemanglerDemangle(t *testing.T) {
+	cases := []struct {
+		mangled string
+		want    string
+		wantErr bool
+	}{
+		{mangled: "_ZN3foo3barEv", want: "foo::bar"},
+		{mangled: "not_itanium", wantErr: true},
+		{mangled: "_Z", wantErr: true},      // missing length prefix
+		{mangled: "_Z3ab", wantErr: true},   // length 3 exceeds remaining "ab"
+		{mangled: "_ZN3fooEv", want: "foo"}, // single-component nested name
+	}
+	var d ItaniumDemangler
+	for _, c := range cases {
+		got, err := d.Demangle(c.mangled)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("Demangle(%q) = %q, <nil>, want error", c.mangled, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Demangle(%q) failed: %v", c.mangled, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("Demangle(%q) = %q, want %q", c.mangled, got, c.want)
+		}
+	}
+}
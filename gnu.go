@@ -0,0 +1,75 @@
+package mapfile
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseGNUSection parses the whitespace-separated fields of a GNU MAP file
+// section entry.
+func parseGNUSection(fields []string) (*Section, error) {
+	// Example:
+	//
+	//    .text          0x0000000000401000      0x500 /tmp/foo.o
+	name := fields[0]
+	addr, err := strconv.ParseUint(strings.TrimPrefix(fields[1], "0x"), 16, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	size, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "0x"), 16, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Section{
+		Name: name,
+		// GNU MAP files use flat (non-segmented) addresses; segment 0 denotes
+		// the single, flat address space.
+		Start: SegmentOffset{Offset: addr},
+		Size:  int(size),
+		Type:  gnuSectionType(name),
+	}, nil
+}
+
+// gnuSectionType guesses the section type from a GNU section name, there
+// being no explicit CODE/DATA class as in the MSVC dialect.
+func gnuSectionType(name string) SectionType {
+	if strings.HasPrefix(name, ".text") {
+		return SectionTypeCode
+	}
+	return SectionTypeData
+}
+
+// gnuObjectName converts a GNU linker object reference into the
+// "[libname:]filename" form used by Symbol.ObjectName, matching the MSVC
+// dialect (e.g. "kernel32:KERNEL32.dll"). Archive member references of the
+// form "archive.a(member.o)" become "archive.a:member.o"; plain object
+// paths, which name no archive, are returned unchanged.
+func gnuObjectName(raw string) string {
+	open := strings.IndexByte(raw, '(')
+	if open == -1 || !strings.HasSuffix(raw, ")") {
+		return raw
+	}
+	archive := raw[:open]
+	member := raw[open+1 : len(raw)-1]
+	return archive + ":" + member
+}
+
+// parseGNUSymbol parses the whitespace-separated fields of a GNU MAP file
+// symbol entry.
+func parseGNUSymbol(fields []string) (*Symbol, error) {
+	// Example:
+	//
+	//    0x0000000000401000                foo_func
+	addr, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Symbol{
+		MangledName: fields[1],
+		// Name is populated after decoding by the configured demanglers.
+		Addr:  addr,
+		Start: SegmentOffset{Offset: addr},
+	}, nil
+}
@@ -0,0 +1,129 @@
+package mapfile
+
+import "sort"
+
+// mapIndex is a lazily-built, address-sorted index over a Map's symbols and
+// sections, used to answer SymbolAt, SymbolsInRange and SectionAt in
+// O(log n) rather than scanning Syms/Sects on every call.
+type mapIndex struct {
+	// syms is Syms sorted by Addr.
+	syms []*Symbol
+	// sects is Sects sorted by resolved virtual address.
+	sects []*Section
+	// sectVA is the resolved virtual address (start of section) of each
+	// section in sects.
+	sectVA map[*Section]uint64
+}
+
+// index returns m's address index, building it from the current contents
+// of m.Sects and m.Syms on first use.
+func (m *Map) index() *mapIndex {
+	if m.idx == nil {
+		m.idx = buildIndex(m)
+	}
+	return m.idx
+}
+
+// buildIndex builds the address index for m, resolving each section's
+// virtual address and each symbol's Size.
+func buildIndex(m *Map) *mapIndex {
+	idx := &mapIndex{
+		syms:   append([]*Symbol(nil), m.Syms...),
+		sects:  append([]*Section(nil), m.Sects...),
+		sectVA: make(map[*Section]uint64, len(m.Sects)),
+	}
+	sort.Slice(idx.syms, func(i, j int) bool {
+		return idx.syms[i].Addr < idx.syms[j].Addr
+	})
+
+	// Resolve each segment's base virtual address from any symbol it
+	// contains: Addr = segBase + Start.Offset, so segBase = Addr -
+	// Start.Offset. Segment 0, the flat address space used by the GNU
+	// dialect, always resolves to m.BaseAddr.
+	segBase := map[int]uint64{0: m.BaseAddr}
+	for _, sym := range m.Syms {
+		segNum := sym.Start.SegNum
+		if _, ok := segBase[segNum]; !ok {
+			segBase[segNum] = sym.Addr - sym.Start.Offset
+		}
+	}
+	for _, sect := range idx.sects {
+		idx.sectVA[sect] = segBase[sect.Start.SegNum] + sect.Start.Offset
+	}
+	sort.Slice(idx.sects, func(i, j int) bool {
+		return idx.sectVA[idx.sects[i]] < idx.sectVA[idx.sects[j]]
+	})
+
+	// Populate each symbol's Size as the distance to the next symbol in the
+	// same section, with the section's end as sentinel for the last
+	// symbol.
+	for _, sect := range idx.sects {
+		va := idx.sectVA[sect]
+		end := va + uint64(sect.Size)
+		var group []*Symbol
+		for _, sym := range idx.syms {
+			if sym.Addr >= va && sym.Addr < end {
+				group = append(group, sym)
+			}
+		}
+		for i, sym := range group {
+			symEnd := end
+			if i+1 < len(group) {
+				symEnd = group[i+1].Addr
+			}
+			if symEnd > sym.Addr {
+				sym.Size = symEnd - sym.Addr
+			}
+		}
+	}
+	return idx
+}
+
+// SymbolAt returns the symbol with the greatest address not exceeding addr,
+// or nil if m has no symbol at or before addr.
+func (m *Map) SymbolAt(addr uint64) *Symbol {
+	syms := m.index().syms
+	i := sort.Search(len(syms), func(i int) bool {
+		return syms[i].Addr > addr
+	})
+	if i == 0 {
+		return nil
+	}
+	return syms[i-1]
+}
+
+// SymbolsInRange returns the symbols whose address lies in [lo, hi), sorted
+// by address.
+func (m *Map) SymbolsInRange(lo, hi uint64) []*Symbol {
+	syms := m.index().syms
+	i := sort.Search(len(syms), func(i int) bool {
+		return syms[i].Addr >= lo
+	})
+	j := sort.Search(len(syms), func(i int) bool {
+		return syms[i].Addr >= hi
+	})
+	if i >= j {
+		return nil
+	}
+	out := make([]*Symbol, j-i)
+	copy(out, syms[i:j])
+	return out
+}
+
+// SectionAt returns the section containing addr, or nil if addr does not
+// fall within any known section.
+func (m *Map) SectionAt(addr uint64) *Section {
+	idx := m.index()
+	sects := idx.sects
+	i := sort.Search(len(sects), func(i int) bool {
+		return idx.sectVA[sects[i]] > addr
+	})
+	if i == 0 {
+		return nil
+	}
+	sect := sects[i-1]
+	if va := idx.sectVA[sect]; addr >= va+uint64(sect.Size) {
+		return nil
+	}
+	return sect
+}
@@ -0,0 +1,82 @@
+package mapfile
+
+import "testing"
+
+// newIndexTestMap builds a Map with two sections: .text (containing two
+// symbols, so the first symbol's Size is bounded by the next symbol) and
+// .data (containing a single symbol, so its Size is bounded by the
+// section's end).
+func newIndexTestMap() *Map {
+	sect1 := &Section{Name: ".text", Start: SegmentOffset{Offset: 0x0}, Size: 0x100, Type: SectionTypeCode}
+	sect2 := &Section{Name: ".data", Start: SegmentOffset{Offset: 0x100}, Size: 0x50, Type: SectionTypeData}
+	sym1 := &Symbol{MangledName: "sym1", Addr: 0x1010, Start: SegmentOffset{Offset: 0x10}}
+	sym2 := &Symbol{MangledName: "sym2", Addr: 0x1020, Start: SegmentOffset{Offset: 0x20}}
+	sym3 := &Symbol{MangledName: "sym3", Addr: 0x1110, Start: SegmentOffset{Offset: 0x110}}
+	return &Map{
+		BaseAddr: 0x1000,
+		Sects:    []*Section{sect1, sect2},
+		Syms:     []*Symbol{sym1, sym2, sym3},
+	}
+}
+
+func TestSymbolAt(t *testing.T) {
+	m := newIndexTestMap()
+	cases := []struct {
+		addr uint64
+		want string // MangledName, or "" for nil
+	}{
+		{addr: 0x1005, want: ""},     // before the first symbol
+		{addr: 0x1010, want: "sym1"}, // exact address of sym1
+		{addr: 0x1015, want: "sym1"}, // between sym1 and sym2
+		{addr: 0x1020, want: "sym2"}, // exact address of sym2
+	}
+	for _, c := range cases {
+		got := m.SymbolAt(c.addr)
+		switch {
+		case c.want == "" && got != nil:
+			t.Errorf("SymbolAt(%#x) = %q, want nil", c.addr, got.MangledName)
+		case c.want != "" && got == nil:
+			t.Errorf("SymbolAt(%#x) = nil, want %q", c.addr, c.want)
+		case c.want != "" && got.MangledName != c.want:
+			t.Errorf("SymbolAt(%#x) = %q, want %q", c.addr, got.MangledName, c.want)
+		}
+	}
+}
+
+func TestSymbolSize(t *testing.T) {
+	m := newIndexTestMap()
+	// Force the index (and Size) to be computed.
+	m.SymbolAt(0)
+	sym1 := m.Syms[0]
+	if sym1.Size != 0x10 {
+		t.Errorf("sym1.Size = %#x, want %#x (bounded by next symbol)", sym1.Size, 0x10)
+	}
+	sym3 := m.Syms[2]
+	if sym3.Size != 0x40 {
+		t.Errorf("sym3.Size = %#x, want %#x (bounded by section end)", sym3.Size, 0x40)
+	}
+}
+
+func TestSectionAt(t *testing.T) {
+	m := newIndexTestMap()
+	cases := []struct {
+		addr uint64
+		want string // section Name, or "" for nil
+	}{
+		{addr: 0x500, want: ""}, // before the first section
+		{addr: 0x1005, want: ".text"},
+		{addr: 0x1100, want: ".data"}, // exact boundary between sections
+		{addr: 0x2000, want: ""},      // past the last section
+	}
+	for _, c := range cases {
+		got := m.SectionAt(c.addr)
+		switch {
+		case c.want == "" && got != nil:
+			t.Errorf("SectionAt(%#x) = %q, want nil", c.addr, got.Name)
+		case c.want != "" && got == nil:
+			t.Errorf("SectionAt(%#x) = nil, want %q", c.addr, c.want)
+		case c.want != "" && got.Name != c.want:
+			t.Errorf("SectionAt(%#x) = %q, want %q", c.addr, got.Name, c.want)
+		}
+	}
+}
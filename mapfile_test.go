@@ -0,0 +1,101 @@
+package mapfile
+
+import (
+	"os"
+	"testing"
+)
+
+func parseTestdata(t *testing.T, name string) *Map {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := Parse(f)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", name, err)
+	}
+	return m
+}
+
+func findSymbol(m *Map, mangledName string) *Symbol {
+	for _, sym := range m.Syms {
+		if sym.MangledName == mangledName {
+			return sym
+		}
+	}
+	return nil
+}
+
+func TestParseMSVC(t *testing.T) {
+	m := parseTestdata(t, "msvc.map")
+	if m.Dialect != DialectMSVC {
+		t.Errorf("Dialect = %v, want %v", m.Dialect, DialectMSVC)
+	}
+	if m.Name != "foo.exe" {
+		t.Errorf("Name = %q, want %q", m.Name, "foo.exe")
+	}
+	if m.BaseAddr != 0x00400000 {
+		t.Errorf("BaseAddr = %#x, want %#x", m.BaseAddr, 0x00400000)
+	}
+	if len(m.Sects) != 2 {
+		t.Fatalf("len(Sects) = %d, want 2", len(m.Sects))
+	}
+	main := findSymbol(m, "_main")
+	if main == nil {
+		t.Fatal("_main not found")
+	}
+	if !main.IsFunc || main.IsStatic {
+		t.Errorf("_main: IsFunc=%v, IsStatic=%v, want true, false", main.IsFunc, main.IsStatic)
+	}
+	counter := findSymbol(m, "g_counter")
+	if counter == nil {
+		t.Fatal("g_counter not found")
+	}
+	if counter.IsFunc {
+		t.Errorf("g_counter.IsFunc = true, want false")
+	}
+	helper := findSymbol(m, "_helper")
+	if helper == nil {
+		t.Fatal("_helper not found")
+	}
+	if !helper.IsStatic {
+		t.Errorf("_helper.IsStatic = false, want true")
+	}
+}
+
+func TestParseGNU(t *testing.T) {
+	m := parseTestdata(t, "gnu.map")
+	if m.Dialect != DialectGNU {
+		t.Errorf("Dialect = %v, want %v", m.Dialect, DialectGNU)
+	}
+	if m.Name != "a.out" {
+		t.Errorf("Name = %q, want %q", m.Name, "a.out")
+	}
+	for _, sect := range m.Sects {
+		if sect.Name == ".group" {
+			t.Errorf("discarded section %q leaked into Sects", sect.Name)
+		}
+	}
+	barFunc := findSymbol(m, "bar_func")
+	if barFunc == nil {
+		t.Fatal("bar_func not found")
+	}
+	if !barFunc.IsFunc {
+		t.Errorf("bar_func.IsFunc = false, want true (in .text section)")
+	}
+	if want := "/tmp/libbar.a:bar.o"; barFunc.ObjectName != want {
+		t.Errorf("bar_func.ObjectName = %q, want %q", barFunc.ObjectName, want)
+	}
+	fooData := findSymbol(m, "foo_data")
+	if fooData == nil {
+		t.Fatal("foo_data not found")
+	}
+	if fooData.IsFunc {
+		t.Errorf("foo_data.IsFunc = true, want false (in .data section)")
+	}
+	if want := "/tmp/foo.o"; fooData.ObjectName != want {
+		t.Errorf("foo_data.ObjectName = %q, want %q", fooData.ObjectName, want)
+	}
+}
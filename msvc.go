@@ -0,0 +1,108 @@
+package mapfile
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// hasFields reports whether the given line contains the specified fields, as
+// separated by whitespace.
+func hasFields(line string, fields []string) bool {
+	got := strings.Fields(line)
+	if len(fields) != len(got) {
+		return false
+	}
+	for i := range fields {
+		want := fields[i]
+		if want != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSection parses the string representation of the given section.
+func parseSection(s string) (*Section, error) {
+	// Example:
+	//
+	//    0001:00000000 001012c6H .text                   CODE
+	fields := strings.Fields(s)
+	sect := &Section{}
+	// Start of section (offset relative to segment).
+	//
+	//    0001:00000000
+	rawStart := fields[0]
+	start, err := parseSegmentOffset(rawStart)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sect.Start = start
+	// Size in bytes.
+	//
+	//    001012c6H
+	rawSize := strings.TrimSuffix(fields[1], "H")
+	size, err := strconv.ParseUint(rawSize, 16, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sect.Size = int(size)
+	// Section name.
+	//
+	//    .text
+	sect.Name = fields[2]
+	// Section type.
+	//
+	//    CODE
+	sect.Type = SectionTypeFromString(fields[3])
+	return sect, nil
+}
+
+// parseSymbol parses the string representation of the given symbol.
+func parseSymbol(s string) (*Symbol, error) {
+	// Example:
+	//
+	//    0001:00000000       ?bar@@YIXH@Z               00401000 f baz.obj
+	sym := &Symbol{}
+	fields := strings.Fields(s)
+	// Start of symbol (offset relative to segment).
+	//
+	//    0001:00000000
+	rawStart := fields[0]
+	start, err := parseSegmentOffset(rawStart)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sym.Start = start
+	// Symbol name.
+	//
+	//    ?bar@@YIXH@Z
+	sym.MangledName = fields[1]
+	// Address of symbol.
+	//
+	//    00401000
+	rawAddr := fields[2]
+	addr, err := strconv.ParseUint(rawAddr, 16, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sym.Addr = addr
+	// (optional) Symbol type.
+	//
+	//    f
+	if len(fields) == 5 {
+		rawSymbolType := fields[3]
+		switch rawSymbolType {
+		case "f":
+			sym.IsFunc = true
+		default:
+			return nil, errors.Errorf("support for symbol type %q not yet implemented", rawSymbolType)
+		}
+	}
+	// Object name.
+	//
+	//    baz.obj
+	sym.ObjectName = fields[len(fields)-1]
+	return sym, nil
+}
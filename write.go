@@ -0,0 +1,140 @@
+package mapfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WriteTo serialises m into MSVC-format MAP file text, writing to w. The
+// dialect m was parsed from has no bearing on the output; WriteTo always
+// emits the MSVC dialect.
+func (m *Map) WriteTo(w io.Writer) (n int64, err error) {
+	var total int64
+	write := func(format string, args ...interface{}) bool {
+		k, werr := fmt.Fprintf(w, format+"\n", args...)
+		total += int64(k)
+		if werr != nil {
+			err = errors.WithStack(werr)
+			return false
+		}
+		return true
+	}
+
+	if !write("%s", m.Name) {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+	if !write("Timestamp is %08x (%s)", uint32(m.Date.Unix()), m.Date.Format(time.ANSIC)) {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+	if !write("Preferred load address is %08x", m.BaseAddr) {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+
+	if !write("Start         Length     Name                   Class") {
+		return total, err
+	}
+	for _, sect := range m.Sects {
+		if !write("%04X:%08X %08XH %-23s %s", sect.Start.SegNum, sect.Start.Offset, sect.Size, sect.Name, sect.Type) {
+			return total, err
+		}
+	}
+	if !write("") {
+		return total, err
+	}
+
+	if !write(" Address         Publics by Value              Rva+Base   Lib:Object") {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+	for _, sym := range m.Syms {
+		if sym.IsStatic {
+			continue
+		}
+		if !writeSymbol(write, sym) {
+			return total, err
+		}
+	}
+	if !write("") {
+		return total, err
+	}
+
+	if !write("entry point at        %04X:%08X", m.Entry.SegNum, m.Entry.Offset) {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+
+	if !write("Static symbols") {
+		return total, err
+	}
+	if !write("") {
+		return total, err
+	}
+	for _, sym := range m.Syms {
+		if !sym.IsStatic {
+			continue
+		}
+		if !writeSymbol(write, sym) {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// writeSymbol writes a single symbol line using write, reporting whether the
+// write succeeded.
+func writeSymbol(write func(format string, args ...interface{}) bool, sym *Symbol) bool {
+	// 0001:00000000       ?bar@@YIXH@Z               00401000 f baz.obj
+	if sym.IsFunc {
+		return write("%04X:%08X       %-27s %08X f %s", sym.Start.SegNum, sym.Start.Offset, sym.MangledName, sym.Addr, sym.ObjectName)
+	}
+	return write("%04X:%08X       %-27s %08X   %s", sym.Start.SegNum, sym.Start.Offset, sym.MangledName, sym.Addr, sym.ObjectName)
+}
+
+// Format serialises m into MSVC-format MAP file text, returning the result
+// as a string.
+func (m *Map) Format() string {
+	buf := m.Encode()
+	return string(buf)
+}
+
+// Encode serialises m into MSVC-format MAP file text, returning the result
+// as a byte slice.
+func (m *Map) Encode() []byte {
+	var buf bytes.Buffer
+	// WriteTo to a bytes.Buffer never fails.
+	if _, err := m.WriteTo(&buf); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// Rebase shifts Addr on all of m's symbols by the difference between
+// newBase and m's current BaseAddr, and updates BaseAddr to newBase.
+func (m *Map) Rebase(newBase uint64) {
+	delta := int64(newBase) - int64(m.BaseAddr)
+	for _, sym := range m.Syms {
+		sym.Addr = uint64(int64(sym.Addr) + delta)
+	}
+	m.BaseAddr = newBase
+	// Invalidate the cached lookup index; section and symbol addresses have
+	// shifted.
+	m.idx = nil
+}
@@ -0,0 +1,17 @@
+package mapfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWriteToRoundTrip(t *testing.T) {
+	m1 := parseTestdata(t, "msvc.map")
+	m2, err := ParseString(m1.Format())
+	if err != nil {
+		t.Fatalf("Parse(Format()) failed: %v", err)
+	}
+	if !reflect.DeepEqual(m1, m2) {
+		t.Fatalf("round-trip mismatch:\nbefore: %#v\nafter:  %#v", m1, m2)
+	}
+}